@@ -0,0 +1,511 @@
+// Package gobatch batches items read from a Source and hands the resulting
+// batches to a Processor, controlling how batches are sized and how often
+// they're flushed.
+package gobatch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MasterOfBinary/gobatch/processor"
+	"github.com/MasterOfBinary/gobatch/source"
+)
+
+// Service is the lifecycle contract implemented by Batch. It follows the
+// same Start/Stop/Wait/IsRunning shape used throughout the Tendermint
+// codebase: Start and Stop are idempotent-safe (a second call returns an
+// error instead of panicking or blocking), and Wait blocks until the batch
+// has fully drained.
+type Service interface {
+	Start(ctx context.Context, s source.Source, p processor.Processor) error
+	Stop() error
+	Wait()
+	IsRunning() bool
+}
+
+const (
+	stateInitial uint32 = iota
+	stateRunning
+	stateStopped
+)
+
+// BatchConfig configures how a Batch reads and groups items before handing
+// them to a Processor.
+//
+// A batch is flushed (sent to the Processor) as soon as either of the Max
+// thresholds is hit. Once MinItems items have accumulated, the batch is also
+// flushed as soon as MinTime has elapsed since the first item of the batch
+// was read. A zero value for any field disables that constraint.
+type BatchConfig struct {
+	MinItems uint64
+	MaxItems uint64
+
+	MinTime time.Duration
+	MaxTime time.Duration
+
+	// ReadConcurrency is the number of goroutines used to call Source.Read
+	// concurrently. A value of 0 is treated as 1.
+	ReadConcurrency int
+
+	// Clock provides the notion of time used to schedule MinTime/MaxTime
+	// flushes. A nil Clock is treated as RealClock{}; tests can substitute
+	// gobatch/clocktest.FakeClock to make that scheduling deterministic.
+	Clock Clock
+
+	// Deduper, if set, collapses duplicate items within a batch window
+	// before the Processor sees them. See Deduper for details.
+	Deduper *Deduper
+
+	// ProcessConcurrency bounds how many flushed batches can be handed to
+	// the Processor at once; further flushes wait in a FIFO queue until a
+	// slot frees up. A value of 0 means unbounded.
+	ProcessConcurrency int
+}
+
+// Stats reports a Batch's current processing saturation.
+type Stats struct {
+	// Running is the number of batches currently being processed.
+	Running uint64
+	// Waiting is the number of flushed batches queued behind
+	// BatchConfig.ProcessConcurrency, waiting for a slot to free up.
+	Waiting uint64
+	// TotalProcessed is the total number of items in every flushed batch
+	// whose Processor call has returned so far.
+	TotalProcessed uint64
+}
+
+// processJob is a flushed batch waiting to be (or being) handed to the
+// Processor.
+type processJob struct {
+	items       []interface{}
+	dedupResult map[string]*Result
+}
+
+// Batch reads items from a Source, groups them into batches according to its
+// BatchConfig, and hands each batch to a Processor. The zero value is a
+// valid Batch with a default BatchConfig.
+//
+// Batch implements Service, so it can be started and stopped at most once;
+// use New to construct one with a validated, non-default BatchConfig.
+type Batch struct {
+	config *BatchConfig
+
+	// startMu serializes Start attempts against each other so that
+	// cancel/doneCh/errCh are always fully initialized before state is
+	// published as stateRunning. Stop and IsRunning don't need it: by the
+	// time they observe stateRunning through the atomic state field, the
+	// Go memory model guarantees they also observe the writes below that
+	// happened-before it.
+	startMu sync.Mutex
+	state   uint32
+
+	cancel context.CancelFunc
+	doneCh chan struct{}
+	errCh  chan error
+
+	procMu         sync.Mutex
+	running        int
+	procQueue      []processJob
+	totalProcessed uint64
+}
+
+var _ Service = (*Batch)(nil)
+
+// New creates a Batch using config. A nil config is equivalent to the zero
+// value of BatchConfig. New returns an error if config is internally
+// inconsistent, e.g. MaxItems less than MinItems.
+func New(config *BatchConfig) (*Batch, error) {
+	if config != nil {
+		if config.MaxItems > 0 && config.MinItems > config.MaxItems {
+			return nil, errors.New("gobatch: MinItems must not be greater than MaxItems")
+		}
+		if config.MaxTime > 0 && config.MinTime > config.MaxTime {
+			return nil, errors.New("gobatch: MinTime must not be greater than MaxTime")
+		}
+	}
+
+	return &Batch{config: config}, nil
+}
+
+// Must is a helper that wraps a call returning (*Batch, error) and panics if
+// the error is non-nil. It's intended for use with New in variable
+// initialization, e.g. batch := Must(New(config)).
+func Must(batch *Batch, err error) *Batch {
+	if err != nil {
+		panic(err)
+	}
+	return batch
+}
+
+// IsRunning reports whether the Batch is currently between a successful
+// Start and a Stop.
+func (b *Batch) IsRunning() bool {
+	return atomic.LoadUint32(&b.state) == stateRunning
+}
+
+// Stats reports how many flushed batches are currently being processed or
+// waiting to be, and how many items have been processed in total.
+func (b *Batch) Stats() Stats {
+	b.procMu.Lock()
+	defer b.procMu.Unlock()
+
+	return Stats{
+		Running:        uint64(b.running),
+		Waiting:        uint64(len(b.procQueue)),
+		TotalProcessed: atomic.LoadUint64(&b.totalProcessed),
+	}
+}
+
+// Start begins reading from s and processing batches with p. It returns
+// ErrAlreadyStarted if the Batch has already been started, whether or not it
+// has since been stopped.
+//
+// Callers must read from Errs() once Start returns: it's unbuffered, and a
+// Source or Processor that ever reports an error will block run() trying to
+// send to it, which in turn blocks Stop and Wait forever.
+func (b *Batch) Start(ctx context.Context, s source.Source, p processor.Processor) error {
+	b.startMu.Lock()
+	defer b.startMu.Unlock()
+
+	if atomic.LoadUint32(&b.state) != stateInitial {
+		return ErrAlreadyStarted
+	}
+
+	config := b.config
+	if config == nil {
+		config = &BatchConfig{}
+	}
+	if config.Clock == nil {
+		configCopy := *config
+		configCopy.Clock = RealClock{}
+		config = &configCopy
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	b.cancel = cancel
+	b.doneCh = make(chan struct{})
+	b.errCh = make(chan error)
+
+	// Only publish stateRunning once cancel/doneCh/errCh above are fully
+	// initialized: Stop and IsRunning key off this store, and startMu
+	// guarantees no other Start call is racing to do the same.
+	atomic.StoreUint32(&b.state, stateRunning)
+
+	go b.run(runCtx, config, s, p)
+
+	return nil
+}
+
+// Stop cancels the Batch's context, flushes whatever items have already been
+// read into a final batch (ignoring MinTime, since there's no more reading
+// left to wait on), waits for every in-flight Processor call to finish, and
+// then closes the error channel. It returns ErrAlreadyStopped if the Batch
+// was never started or has already been stopped.
+//
+// Stop blocks until the Batch has fully drained, so it should not be called
+// from the same goroutine that reads the error channel returned by Start.
+func (b *Batch) Stop() error {
+	if !atomic.CompareAndSwapUint32(&b.state, stateRunning, stateStopped) {
+		return ErrAlreadyStopped
+	}
+
+	b.cancel()
+	<-b.doneCh
+
+	return nil
+}
+
+// Wait blocks until the Batch has fully drained, i.e. until the Source has
+// closed, every item it produced has been flushed to the Processor, and
+// every Processor call has returned.
+func (b *Batch) Wait() {
+	<-b.doneCh
+}
+
+// Done returns a channel that's closed once the Batch has fully drained. It
+// predates Wait and is kept for backwards compatibility.
+func (b *Batch) Done() <-chan struct{} {
+	return b.doneCh
+}
+
+// Errs returns the channel of asynchronous errors produced while the Batch
+// runs: *SourceError values from s.Read and *ProcessorError values from
+// p.Process. It's closed once the Batch has fully drained, same as Done.
+// Callers must keep reading it for as long as the Batch runs; see Start.
+func (b *Batch) Errs() <-chan error {
+	return b.errCh
+}
+
+// Go is a thin wrapper around Start kept for backwards compatibility. Unlike
+// Start, it panics if the Batch has already been started, and it returns the
+// error channel directly instead of requiring a separate call to Errs.
+func (b *Batch) Go(ctx context.Context, s source.Source, p processor.Processor) <-chan error {
+	if err := b.Start(ctx, s, p); err != nil {
+		panic(err)
+	}
+	return b.Errs()
+}
+
+// run reads from s, accumulates batches per config, hands each one to p, and
+// closes doneCh/errCh once everything has drained.
+func (b *Batch) run(ctx context.Context, config *BatchConfig, s source.Source, p processor.Processor) {
+	defer close(b.doneCh)
+	defer close(b.errCh)
+
+	var processWG sync.WaitGroup
+	defer processWG.Wait()
+
+	items := b.readItems(ctx, config, s)
+
+	var (
+		batchItems     []interface{}
+		dedupIndex     map[string]int
+		minC           <-chan time.Time
+		maxC           <-chan time.Time
+		minTimer       Timer
+		maxTimer       Timer
+		minTimeElapsed bool
+	)
+	stopTimers := func() {
+		if minTimer != nil {
+			minTimer.Stop()
+			minTimer, minC = nil, nil
+		}
+		if maxTimer != nil {
+			maxTimer.Stop()
+			maxTimer, maxC = nil, nil
+		}
+	}
+	defer stopTimers()
+
+	flush := func(force bool) {
+		if len(batchItems) == 0 {
+			return
+		}
+		if !force && config.MinItems > 0 && uint64(len(batchItems)) < config.MinItems {
+			return
+		}
+
+		toProcess := batchItems
+		batchItems = nil
+		dedupIndex = nil
+		minTimeElapsed = false
+		stopTimers()
+
+		var dedupResults map[string]*Result
+		if config.Deduper != nil {
+			dedupResults = config.Deduper.take()
+		}
+
+		b.enqueueProcess(ctx, config, p, processJob{items: toProcess, dedupResult: dedupResults}, &processWG)
+	}
+
+	addItem := func(item interface{}) {
+		if config.Deduper != nil {
+			if key, dedupable := config.Deduper.KeyFunc(item); dedupable {
+				if dedupIndex == nil {
+					dedupIndex = make(map[string]int)
+				}
+				if idx, duplicate := dedupIndex[key]; duplicate {
+					if merge := config.Deduper.MergeFunc; merge != nil {
+						batchItems[idx] = merge(batchItems[idx], item)
+					}
+					config.Deduper.Join(key)
+					return
+				}
+				config.Deduper.Join(key)
+				dedupIndex[key] = len(batchItems)
+			}
+		}
+		batchItems = append(batchItems, item)
+	}
+
+	var sourceDone bool
+
+	for {
+		select {
+		case item, ok := <-items:
+			if !ok {
+				items = nil
+				sourceDone = true
+
+				if ctx.Err() != nil {
+					// Stop canceled the context: there's no more reading
+					// left to wait on, so drain unconditionally, ignoring
+					// MinItems/MinTime.
+					flush(true)
+				} else if minTimer == nil && maxTimer == nil {
+					// The Source exhausted on its own and no timer is
+					// pending to eventually enforce MinTime/MaxTime: nothing
+					// else will ever flush this, so do it now.
+					flush(true)
+				}
+				// Otherwise the Source exhausted on its own but a timer is
+				// still pending: leave the batch as-is and let minC/maxC
+				// flush it below, respecting MinItems/MinTime in the
+				// meantime.
+				break
+			}
+
+			if len(batchItems) == 0 {
+				if config.MinTime > 0 {
+					minTimer = config.Clock.NewTimer(config.MinTime)
+					minC = minTimer.C()
+				}
+				if config.MaxTime > 0 {
+					maxTimer = config.Clock.NewTimer(config.MaxTime)
+					maxC = maxTimer.C()
+				}
+			}
+			addItem(item)
+
+			if config.MaxItems > 0 && uint64(len(batchItems)) >= config.MaxItems {
+				flush(true)
+			} else if config.MinTime == 0 || minTimeElapsed {
+				// Either there's no MinTime to wait on, or the minTimer already
+				// fired while the batch was still under MinItems: this item may
+				// be the one that finally crosses it, so check again now
+				// instead of waiting on a timer that's already gone off.
+				flush(false)
+			}
+		case <-minC:
+			// minTimer has already fired and won't fire again: stop treating
+			// it as a pending timer so source exhaustion above doesn't wait
+			// on it forever.
+			minC, minTimer = nil, nil
+			if !sourceDone && config.MinItems > 0 && uint64(len(batchItems)) < config.MinItems {
+				// Not enough items yet and the Source may still produce
+				// more: remember that MinTime has elapsed so the next item
+				// to arrive re-checks the flush condition, instead of
+				// waiting on a timer that won't fire again.
+				minTimeElapsed = true
+				break
+			}
+			// Either there's enough items, or the Source is exhausted and
+			// nothing will ever arrive to satisfy MinItems: flush now.
+			flush(true)
+		case <-maxC:
+			flush(true)
+		}
+
+		if sourceDone && len(batchItems) == 0 {
+			return
+		}
+	}
+}
+
+// enqueueProcess hands job to p, subject to config.ProcessConcurrency: if
+// fewer than that many batches are already running, job starts immediately;
+// otherwise it waits in a FIFO queue until a running batch finishes. wg
+// tracks job from the moment it's enqueued, not from when it actually starts
+// running, so Stop can tell the difference between "nothing left to do" and
+// "still waiting for a slot".
+func (b *Batch) enqueueProcess(ctx context.Context, config *BatchConfig, p processor.Processor, job processJob, wg *sync.WaitGroup) {
+	wg.Add(1)
+
+	b.procMu.Lock()
+	if config.ProcessConcurrency > 0 && b.running >= config.ProcessConcurrency {
+		b.procQueue = append(b.procQueue, job)
+		b.procMu.Unlock()
+		return
+	}
+	b.running++
+	b.procMu.Unlock()
+
+	go b.process(ctx, config, p, job, wg)
+}
+
+// process hands job to p, forwards every error it returns (wrapped as a
+// *ProcessorError) to b.errCh, and broadcasts the first such error to every
+// Result in job.dedupResult, the dedup window this batch was flushed from.
+// Once done, it wakes the next queued job, if any.
+func (b *Batch) process(ctx context.Context, config *BatchConfig, p processor.Processor, job processJob, wg *sync.WaitGroup) {
+	errs := make(chan error)
+	go p.Process(ctx, job.items, errs)
+
+	var firstErr error
+	for err := range errs {
+		if firstErr == nil {
+			firstErr = err
+		}
+		b.errCh <- &ProcessorError{err: err}
+	}
+
+	atomic.AddUint64(&b.totalProcessed, uint64(len(job.items)))
+	for _, r := range job.dedupResult {
+		r.broadcast(firstErr)
+	}
+
+	b.dequeueProcess(ctx, config, p, wg)
+	wg.Done()
+}
+
+// dequeueProcess hands the next waiting job, if any, straight to the slot
+// just freed by a finished one; otherwise it records that the slot is free.
+func (b *Batch) dequeueProcess(ctx context.Context, config *BatchConfig, p processor.Processor, wg *sync.WaitGroup) {
+	b.procMu.Lock()
+	if len(b.procQueue) == 0 {
+		b.running--
+		b.procMu.Unlock()
+		return
+	}
+
+	next := b.procQueue[0]
+	b.procQueue = b.procQueue[1:]
+	b.procMu.Unlock()
+
+	go b.process(ctx, config, p, next, wg)
+}
+
+// readItems launches config.ReadConcurrency goroutines calling s.Read and
+// fans their items into a single channel. The returned channel is closed
+// once every Read call has returned; if ctx is canceled, readItems drains
+// whatever's already in flight before closing rather than dropping it, so
+// Stop can flush a final batch.
+func (b *Batch) readItems(ctx context.Context, config *BatchConfig, s source.Source) <-chan interface{} {
+	concurrency := config.ReadConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	items := make(chan interface{})
+
+	var readWG sync.WaitGroup
+	readWG.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer readWG.Done()
+
+			srcItems := make(chan interface{})
+			srcErrs := make(chan error)
+			go s.Read(ctx, srcItems, srcErrs)
+
+			for srcItems != nil || srcErrs != nil {
+				select {
+				case item, ok := <-srcItems:
+					if !ok {
+						srcItems = nil
+						continue
+					}
+					items <- item
+				case err, ok := <-srcErrs:
+					if !ok {
+						srcErrs = nil
+						continue
+					}
+					b.errCh <- &SourceError{err: err}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		readWG.Wait()
+		close(items)
+	}()
+
+	return items
+}
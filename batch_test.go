@@ -406,3 +406,179 @@ func TestBatch_Done(t *testing.T) {
 		}
 	})
 }
+
+// blockingSource sends items on a channel supplied by the test until ctx is
+// canceled, at which point it closes and returns. It lets tests control
+// exactly when a Batch has something left to drain on Stop.
+//
+// Once an item has been received from in, it's forwarded to items
+// unconditionally: ctx is only checked while waiting for the next item, never
+// after one has already been claimed, so a Stop racing in can't make an item
+// the test already sent vanish before run() ever sees it.
+type blockingSource struct {
+	in <-chan interface{}
+}
+
+func (s *blockingSource) Read(ctx context.Context, items chan<- interface{}, errs chan<- error) {
+	defer close(items)
+	defer close(errs)
+
+	for {
+		select {
+		case item, ok := <-s.in:
+			if !ok {
+				return
+			}
+			items <- item
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func TestBatch_Start(t *testing.T) {
+	t.Run("concurrent racers", func(t *testing.T) {
+		t.Parallel()
+
+		batch := &Batch{}
+		s := source.Nil(0)
+		p := processor.Nil(0)
+
+		const numRacers = 10
+		var wg sync.WaitGroup
+		var started uint32
+		wg.Add(numRacers)
+		for i := 0; i < numRacers; i++ {
+			go func() {
+				defer wg.Done()
+				if err := batch.Start(context.Background(), s, p); err == nil {
+					atomic.AddUint32(&started, 1)
+				} else if err != ErrAlreadyStarted {
+					t.Errorf("Start returned %v, want nil or ErrAlreadyStarted", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if started != 1 {
+			t.Errorf("%d racers succeeded in starting the batch, want 1", started)
+		}
+
+		<-batch.Done()
+	})
+
+	t.Run("already started", func(t *testing.T) {
+		t.Parallel()
+
+		batch := &Batch{}
+		s := source.Nil(0)
+		p := processor.Nil(0)
+
+		if err := batch.Start(context.Background(), s, p); err != nil {
+			t.Fatalf("Start returned %v, want nil", err)
+		}
+
+		if err := batch.Start(context.Background(), s, p); err != ErrAlreadyStarted {
+			t.Errorf("Start returned %v, want ErrAlreadyStarted", err)
+		}
+
+		<-batch.Done()
+	})
+
+	t.Run("reading Errs lets a reporting Processor's batch finish", func(t *testing.T) {
+		t.Parallel()
+
+		errProcess := errors.New("boom")
+		s := &sourceFromSlice{slice: []interface{}{1}}
+		p := processor.Error(errProcess)
+
+		batch := &Batch{}
+		if err := batch.Start(context.Background(), s, p); err != nil {
+			t.Fatalf("Start returned %v, want nil", err)
+		}
+
+		var got error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for err := range batch.Errs() {
+				got = err
+			}
+		}()
+
+		select {
+		case <-batch.Done():
+		case <-time.After(time.Second):
+			t.Fatal("batch never finished; Errs() wasn't draining it")
+		}
+		<-done
+
+		if perr, ok := got.(*ProcessorError); !ok || perr.Original() != errProcess {
+			t.Errorf("Errs() delivered %v, want a *ProcessorError wrapping %v", got, errProcess)
+		}
+	})
+}
+
+func TestBatch_Stop(t *testing.T) {
+	t.Run("stop before start", func(t *testing.T) {
+		t.Parallel()
+
+		batch := &Batch{}
+		if err := batch.Stop(); err != ErrAlreadyStopped {
+			t.Errorf("Stop returned %v, want ErrAlreadyStopped", err)
+		}
+	})
+
+	t.Run("double stop", func(t *testing.T) {
+		t.Parallel()
+
+		batch := &Batch{}
+		s := source.Nil(0)
+		p := processor.Nil(0)
+
+		if err := batch.Start(context.Background(), s, p); err != nil {
+			t.Fatalf("Start returned %v, want nil", err)
+		}
+		<-batch.Done()
+
+		if err := batch.Stop(); err != nil {
+			t.Errorf("First Stop returned %v, want nil", err)
+		}
+		if err := batch.Stop(); err != ErrAlreadyStopped {
+			t.Errorf("Second Stop returned %v, want ErrAlreadyStopped", err)
+		}
+	})
+
+	t.Run("stop during read flushes a final batch", func(t *testing.T) {
+		t.Parallel()
+
+		in := make(chan interface{})
+		s := &blockingSource{in: in}
+		p := &processorCounter{}
+
+		batch := Must(New(&BatchConfig{MinItems: 100}))
+		if err := batch.Start(context.Background(), s, p); err != nil {
+			t.Fatalf("Start returned %v, want nil", err)
+		}
+
+		in <- 1
+		in <- 2
+
+		if batch.IsRunning() != true {
+			t.Error("IsRunning() = false while batch is processing, want true")
+		}
+
+		if err := batch.Stop(); err != nil {
+			t.Errorf("Stop returned %v, want nil", err)
+		}
+
+		if batch.IsRunning() {
+			t.Error("IsRunning() = true after Stop, want false")
+		}
+
+		if got := atomic.LoadUint32(&p.totalCount); got != 2 {
+			t.Errorf("Processor saw %d items, want 2", got)
+		}
+	})
+}
+
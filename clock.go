@@ -0,0 +1,61 @@
+package gobatch
+
+import "time"
+
+// Timer mirrors the subset of *time.Timer that Batch needs, so that a fake
+// implementation can be substituted in tests. See RealClock and, for tests,
+// gobatch/clocktest.FakeClock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker mirrors the subset of *time.Ticker that Batch needs.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Clock abstracts time so that MinTime/MaxTime batching can be driven
+// deterministically in tests instead of waiting on the real clock.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// RealClock is the default Clock, backed by the time package.
+type RealClock struct{}
+
+var _ Clock = RealClock{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer returns a Timer backed by time.NewTimer.
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{timer: time.NewTimer(d)}
+}
+
+// NewTicker returns a Ticker backed by time.NewTicker.
+func (RealClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (r *realTimer) C() <-chan time.Time        { return r.timer.C }
+func (r *realTimer) Stop() bool                 { return r.timer.Stop() }
+func (r *realTimer) Reset(d time.Duration) bool { return r.timer.Reset(d) }
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (r *realTicker) C() <-chan time.Time { return r.ticker.C }
+func (r *realTicker) Stop()               { r.ticker.Stop() }
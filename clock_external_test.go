@@ -0,0 +1,182 @@
+// This file lives in package gobatch_test, not gobatch, because
+// gobatch/clocktest imports gobatch (to implement the Clock/Timer/Ticker
+// interfaces) -- putting these tests in the internal test package would
+// create an import cycle (gobatch's tests -> clocktest -> gobatch).
+package gobatch_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MasterOfBinary/gobatch"
+	"github.com/MasterOfBinary/gobatch/clocktest"
+)
+
+type clockTestSource struct {
+	slice []interface{}
+}
+
+func (s *clockTestSource) Read(ctx context.Context, items chan<- interface{}, errs chan<- error) {
+	defer close(items)
+	defer close(errs)
+
+	for _, item := range s.slice {
+		items <- item
+	}
+}
+
+// clockTestBlockingSource is a copy of batch_test.go's blockingSource: once an
+// item has been received from in, it's forwarded to items unconditionally, so
+// a Stop racing in can't make an already-claimed item vanish.
+type clockTestBlockingSource struct {
+	in <-chan interface{}
+}
+
+func (s *clockTestBlockingSource) Read(ctx context.Context, items chan<- interface{}, errs chan<- error) {
+	defer close(items)
+	defer close(errs)
+
+	for {
+		select {
+		case item, ok := <-s.in:
+			if !ok {
+				return
+			}
+			items <- item
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type clockTestCounter struct {
+	totalCount uint32
+}
+
+func (p *clockTestCounter) Process(ctx context.Context, items []interface{}, errs chan<- error) {
+	atomic.AddUint32(&p.totalCount, uint32(len(items)))
+	close(errs)
+}
+
+func TestBatch_Clock(t *testing.T) {
+	t.Run("MinTime flushes once the clock advances far enough", func(t *testing.T) {
+		t.Parallel()
+
+		clock := clocktest.NewFakeClock()
+		batch := gobatch.Must(gobatch.New(&gobatch.BatchConfig{
+			MinTime: time.Second,
+			Clock:   clock,
+		}))
+		s := &clockTestSource{slice: []interface{}{1}}
+		p := &clockTestCounter{}
+
+		errs := batch.Go(context.Background(), s, p)
+		go func() {
+			for range errs {
+			}
+		}()
+
+		clock.BlockUntil(1)
+
+		select {
+		case <-batch.Done():
+			t.Fatal("batch finished before the MinTime timer fired")
+		default:
+		}
+
+		clock.Advance(time.Second)
+
+		select {
+		case <-batch.Done():
+		case <-time.After(time.Second):
+			t.Fatal("batch never finished after the MinTime timer fired")
+		}
+
+		if got := atomic.LoadUint32(&p.totalCount); got != 1 {
+			t.Errorf("Processor saw %d items, want 1", got)
+		}
+	})
+
+	t.Run("MaxTime forces a flush even under MinItems", func(t *testing.T) {
+		t.Parallel()
+
+		clock := clocktest.NewFakeClock()
+		in := make(chan interface{})
+		batch := gobatch.Must(gobatch.New(&gobatch.BatchConfig{
+			MinItems: 100,
+			MaxTime:  time.Second,
+			Clock:    clock,
+		}))
+		s := &clockTestBlockingSource{in: in}
+		p := &clockTestCounter{}
+
+		errs := batch.Go(context.Background(), s, p)
+		go func() {
+			for range errs {
+			}
+		}()
+
+		in <- 1
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+
+		deadline := time.After(time.Second)
+		for atomic.LoadUint32(&p.totalCount) != 1 {
+			select {
+			case <-deadline:
+				t.Fatalf("Processor saw %d items after MaxTime elapsed, want 1", atomic.LoadUint32(&p.totalCount))
+			case <-time.After(time.Millisecond):
+			}
+		}
+
+		if err := batch.Stop(); err != nil {
+			t.Errorf("Stop returned %v, want nil", err)
+		}
+
+		if got := atomic.LoadUint32(&p.totalCount); got != 1 {
+			t.Errorf("Processor saw %d items, want 1", got)
+		}
+	})
+
+	t.Run("MinTime firing under MinItems doesn't stall later flushes", func(t *testing.T) {
+		t.Parallel()
+
+		clock := clocktest.NewFakeClock()
+		in := make(chan interface{})
+		batch := gobatch.Must(gobatch.New(&gobatch.BatchConfig{
+			MinItems: 3,
+			MinTime:  time.Second,
+			Clock:    clock,
+		}))
+		s := &clockTestBlockingSource{in: in}
+		p := &clockTestCounter{}
+
+		errs := batch.Go(context.Background(), s, p)
+		go func() {
+			for range errs {
+			}
+		}()
+
+		in <- 1
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+
+		in <- 2
+		in <- 3
+
+		deadline := time.After(time.Second)
+		for atomic.LoadUint32(&p.totalCount) != 3 {
+			select {
+			case <-deadline:
+				t.Fatalf("Processor saw %d items after MinItems was reached, want 3", atomic.LoadUint32(&p.totalCount))
+			case <-time.After(time.Millisecond):
+			}
+		}
+
+		if err := batch.Stop(); err != nil {
+			t.Errorf("Stop returned %v, want nil", err)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+// Package clocktest provides a FakeClock implementation of gobatch.Clock for
+// deterministic, race-free tests of MinTime/MaxTime batching. It borrows the
+// logical-clock pattern from Tendermint's timeout ticker tests: time only
+// advances when a test calls Advance, and BlockUntil lets a test wait for
+// the code under test to have actually registered its timers before
+// advancing past them.
+package clocktest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MasterOfBinary/gobatch"
+)
+
+// FakeClock is a gobatch.Clock whose Now only changes when Advance is
+// called. It's safe for concurrent use.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*waiter
+}
+
+var _ gobatch.Clock = (*FakeClock)(nil)
+
+// waiter is the shared state behind both fakeTimer and fakeTicker.
+type waiter struct {
+	fireTime time.Time
+	period   time.Duration // 0 for a one-shot timer, >0 for a ticker
+	c        chan time.Time
+	stopped  bool
+}
+
+// NewFakeClock returns a FakeClock whose Now starts at the Unix epoch.
+func NewFakeClock() *FakeClock {
+	fc := &FakeClock{now: time.Unix(0, 0)}
+	fc.cond = sync.NewCond(&fc.mu)
+	return fc
+}
+
+// Now returns the clock's current logical time.
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// NewTimer registers a one-shot waiter that fires the next time Advance
+// moves the clock to or past d from now.
+func (f *FakeClock) NewTimer(d time.Duration) gobatch.Timer {
+	return &fakeTimer{clock: f, w: f.addWaiter(d, 0)}
+}
+
+// NewTicker registers a repeating waiter that fires every period d.
+func (f *FakeClock) NewTicker(d time.Duration) gobatch.Ticker {
+	return &fakeTicker{clock: f, w: f.addWaiter(d, d)}
+}
+
+func (f *FakeClock) addWaiter(d, period time.Duration) *waiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{
+		fireTime: f.now.Add(d),
+		period:   period,
+		c:        make(chan time.Time, 1),
+	}
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+	return w
+}
+
+// BlockUntil blocks until at least n registered timers/tickers are
+// outstanding (i.e. created and neither stopped nor, for one-shot timers,
+// already fired). It lets a test know that
+// the code under test has actually called NewTimer/NewTicker before the
+// test calls Advance, which is what makes Advance race-free.
+func (f *FakeClock) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for f.activeCountLocked() < n {
+		f.cond.Wait()
+	}
+}
+
+func (f *FakeClock) activeCountLocked() int {
+	count := 0
+	for _, w := range f.waiters {
+		if !w.stopped {
+			count++
+		}
+	}
+	return count
+}
+
+// Advance moves the clock forward by d and fires, in fire-time order, every
+// outstanding timer/ticker whose fire time is now due. Tickers are
+// rescheduled for their next period; one-shot timers are removed once fired.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+
+	f.now = f.now.Add(d)
+	now := f.now
+
+	var due []*waiter
+	for _, w := range f.waiters {
+		if !w.stopped && !w.fireTime.After(now) {
+			due = append(due, w)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].fireTime.Before(due[j].fireTime)
+	})
+
+	remaining := f.waiters[:0:0]
+	for _, w := range f.waiters {
+		if w.stopped {
+			continue
+		}
+		if !w.fireTime.After(now) {
+			if w.period > 0 {
+				w.fireTime = now.Add(w.period)
+				remaining = append(remaining, w)
+			}
+			continue
+		}
+		remaining = append(remaining, w)
+	}
+	f.waiters = remaining
+	f.cond.Broadcast()
+
+	f.mu.Unlock()
+
+	for _, w := range due {
+		select {
+		case w.c <- now:
+		default:
+		}
+	}
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	w     *waiter
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.w.c }
+func (t *fakeTimer) Stop() bool          { return t.clock.stop(t.w) }
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	return t.clock.reset(t.w, d, 0)
+}
+
+type fakeTicker struct {
+	clock *FakeClock
+	w     *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.w.c }
+func (t *fakeTicker) Stop()               { t.clock.stop(t.w) }
+
+func (f *FakeClock) stop(w *waiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wasActive := !w.stopped
+	w.stopped = true
+	f.cond.Broadcast()
+	return wasActive
+}
+
+func (f *FakeClock) reset(w *waiter, d time.Duration, period time.Duration) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wasActive := !w.stopped
+	w.stopped = false
+	w.fireTime = f.now.Add(d)
+	if period == 0 {
+		period = w.period
+	}
+	w.period = period
+	f.cond.Broadcast()
+	return wasActive
+}
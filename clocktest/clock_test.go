@@ -0,0 +1,103 @@
+package clocktest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock_Timer(t *testing.T) {
+	clock := NewFakeClock()
+	timer := clock.NewTimer(time.Second)
+
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before Advance")
+	default:
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(999 * time.Millisecond)
+
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before its duration elapsed")
+	default:
+	}
+
+	clock.Advance(time.Millisecond)
+
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("timer did not fire after Advance reached its duration")
+	}
+}
+
+func TestFakeClock_Ticker(t *testing.T) {
+	clock := NewFakeClock()
+	ticker := clock.NewTicker(time.Second)
+	clock.BlockUntil(1)
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Second)
+		select {
+		case <-ticker.C():
+		default:
+			t.Fatalf("ticker did not fire on tick %d", i)
+		}
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker fired after Stop")
+	default:
+	}
+}
+
+func TestFakeClock_MultipleDueTimers(t *testing.T) {
+	clock := NewFakeClock()
+	first := clock.NewTimer(time.Second)
+	second := clock.NewTimer(2 * time.Second)
+	clock.BlockUntil(2)
+
+	clock.Advance(2 * time.Second)
+
+	select {
+	case <-first.C():
+	default:
+		t.Fatal("first timer did not fire")
+	}
+	select {
+	case <-second.C():
+	default:
+		t.Fatal("second timer did not fire")
+	}
+}
+
+func TestFakeClock_BlockUntil(t *testing.T) {
+	clock := NewFakeClock()
+	done := make(chan struct{})
+
+	go func() {
+		clock.BlockUntil(2)
+		close(done)
+	}()
+
+	clock.NewTimer(time.Second)
+
+	select {
+	case <-done:
+		t.Fatal("BlockUntil(2) returned after only one timer was created")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clock.NewTimer(time.Second)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("BlockUntil(2) never returned after a second timer was created")
+	}
+}
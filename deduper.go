@@ -0,0 +1,90 @@
+package gobatch
+
+import "sync"
+
+// Deduper collapses logically-identical items accumulating in the same
+// batch window before a Processor ever sees them. It's useful for sources
+// that can emit many duplicate items within a single window -- cache
+// warmers, notification fan-in, dedupable RPC requests -- where processing
+// each one separately would be wasted work.
+//
+// Add a Deduper to BatchConfig.Deduper to enable it; the zero value of
+// Deduper is not usable on its own, since KeyFunc is required.
+type Deduper struct {
+	// KeyFunc returns the dedup key for item. If dedupable is false, item is
+	// always passed through to the Processor unchanged.
+	KeyFunc func(item interface{}) (key string, dedupable bool)
+
+	// MergeFunc combines a duplicate incoming item into the one already
+	// accumulated for its key. If nil, the first item seen for a key in the
+	// current batch window is kept and later duplicates are dropped.
+	MergeFunc func(existing, incoming interface{}) interface{}
+
+	mu      sync.Mutex
+	results map[string]*Result
+}
+
+// Result broadcasts a single Processor outcome to every caller that joined
+// the same dedup key during one batch window, mirroring
+// singleflight.Group.Do's shared-result semantics but scoped to a single
+// flush rather than an unbounded lifetime.
+type Result struct {
+	done chan struct{}
+	err  error
+}
+
+func newResult() *Result {
+	return &Result{done: make(chan struct{})}
+}
+
+// Wait blocks until the batch containing this Result's key has been
+// processed, then returns the first error the Processor reported for that
+// batch, if any. Errors are not matched to individual keys: every Result of
+// the batch gets the same error.
+func (r *Result) Wait() error {
+	<-r.done
+	return r.err
+}
+
+func (r *Result) broadcast(err error) {
+	r.err = err
+	close(r.done)
+}
+
+// Join returns the Result for key within the current batch window, creating
+// one if key hasn't been seen yet. Every caller that joins the same key
+// before the window's next flush gets back the same Result. duplicate
+// reports whether key was already present, i.e. whether this call's item
+// should be merged into the existing one rather than appended as new.
+//
+// Batch calls Join itself as it reads items, so callers that want to learn
+// the outcome of an item they're about to submit should call Join with the
+// same key before handing the item to the Source, and then call Wait on the
+// returned Result.
+func (d *Deduper) Join(key string) (result *Result, duplicate bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.results == nil {
+		d.results = make(map[string]*Result)
+	}
+	if r, ok := d.results[key]; ok {
+		return r, true
+	}
+
+	r := newResult()
+	d.results[key] = r
+	return r, false
+}
+
+// take returns every Result outstanding in the current window and clears the
+// window so the next batch starts fresh. The caller is responsible for
+// eventually broadcasting an outcome to the Results it gets back.
+func (d *Deduper) take() map[string]*Result {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	results := d.results
+	d.results = nil
+	return results
+}
@@ -0,0 +1,137 @@
+package gobatch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]interface{}
+	err     error
+}
+
+func (p *batchRecorder) Process(ctx context.Context, items []interface{}, errs chan<- error) {
+	defer close(errs)
+
+	p.mu.Lock()
+	p.batches = append(p.batches, append([]interface{}(nil), items...))
+	p.mu.Unlock()
+
+	if p.err != nil {
+		errs <- p.err
+	}
+}
+
+func drainErrors(errs <-chan error) {
+	go func() {
+		for range errs {
+		}
+	}()
+}
+
+func TestDeduper_Join(t *testing.T) {
+	d := &Deduper{}
+
+	r1, duplicate := d.Join("a")
+	if duplicate {
+		t.Error("first Join for a key reported duplicate = true")
+	}
+
+	r2, duplicate := d.Join("a")
+	if !duplicate {
+		t.Error("second Join for the same key reported duplicate = false")
+	}
+	if r1 != r2 {
+		t.Error("second Join for the same key returned a different Result")
+	}
+
+	r3, duplicate := d.Join("b")
+	if duplicate {
+		t.Error("first Join for a different key reported duplicate = true")
+	}
+	if r3 == r1 {
+		t.Error("Join for different keys returned the same Result")
+	}
+}
+
+func TestDeduper_Take(t *testing.T) {
+	d := &Deduper{}
+	d.Join("a")
+	d.Join("b")
+
+	taken := d.take()
+	if len(taken) != 2 {
+		t.Fatalf("take() returned %d Results, want 2", len(taken))
+	}
+
+	if taken2 := d.take(); len(taken2) != 0 {
+		t.Errorf("second take() returned %d Results, want 0", len(taken2))
+	}
+
+	old := taken["a"]
+	r, duplicate := d.Join("a")
+	if duplicate {
+		t.Error("Join for a key from a previous window reported duplicate = true")
+	}
+	if r == old {
+		t.Error("Join after take() returned a Result from the previous window")
+	}
+}
+
+func TestBatch_Deduper(t *testing.T) {
+	errShared := errors.New("shared")
+
+	dd := &Deduper{
+		KeyFunc: func(item interface{}) (string, bool) {
+			return item.(string), true
+		},
+	}
+
+	recorder := &batchRecorder{err: errShared}
+	in := make(chan interface{})
+	s := &blockingSource{in: in}
+
+	batch := Must(New(&BatchConfig{
+		MinItems: 100,
+		Deduper:  dd,
+	}))
+
+	errs := batch.Go(context.Background(), s, recorder)
+	drainErrors(errs)
+
+	const n = 5
+	results := make([]*Result, n)
+	var joinWG sync.WaitGroup
+	joinWG.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer joinWG.Done()
+			r, _ := dd.Join("same-key")
+			results[i] = r
+			in <- "same-key"
+		}(i)
+	}
+	joinWG.Wait()
+
+	if err := batch.Stop(); err != nil {
+		t.Fatalf("Stop returned %v, want nil", err)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.batches) != 1 {
+		t.Fatalf("Processor saw %d batches, want 1", len(recorder.batches))
+	}
+	if got := recorder.batches[0]; len(got) != 1 {
+		t.Fatalf("Processor's batch had %d items, want 1 (duplicates not collapsed)", len(got))
+	}
+
+	for i, r := range results {
+		if err := r.Wait(); err != errShared {
+			t.Errorf("Result %d returned %v, want %v", i, err, errShared)
+		}
+	}
+}
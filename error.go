@@ -0,0 +1,43 @@
+package gobatch
+
+import "errors"
+
+// ErrAlreadyStarted is returned by Start when the Batch has already been
+// started, whether or not it has since been stopped.
+var ErrAlreadyStarted = errors.New("gobatch: batch already started")
+
+// ErrAlreadyStopped is returned by Stop when the Batch was never started or
+// has already been stopped.
+var ErrAlreadyStopped = errors.New("gobatch: batch already stopped")
+
+// SourceError wraps an error returned by a Source's Read method. Errors sent
+// on the channel returned by Go/Errs are always either a *SourceError or a
+// *ProcessorError, so callers can use a type switch to tell the two apart.
+type SourceError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *SourceError) Error() string {
+	return e.err.Error()
+}
+
+// Original returns the error as it was returned by the Source.
+func (e *SourceError) Original() error {
+	return e.err
+}
+
+// ProcessorError wraps an error returned by a Processor's Process method.
+type ProcessorError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *ProcessorError) Error() string {
+	return e.err.Error()
+}
+
+// Original returns the error as it was returned by the Processor.
+func (e *ProcessorError) Original() error {
+	return e.err
+}
@@ -0,0 +1,22 @@
+package processor
+
+import "context"
+
+type errorProcessor struct {
+	err error
+}
+
+// Error returns a Processor that sends err and then closes immediately. It
+// can be used as a mock Processor.
+func Error(err error) Processor {
+	return &errorProcessor{
+		err: err,
+	}
+}
+
+// Process sends p.err on errs and then closes it.
+func (p *errorProcessor) Process(ctx context.Context, items []interface{}, errs chan<- error) {
+	defer close(errs)
+
+	errs <- p.err
+}
@@ -0,0 +1,28 @@
+package processor
+
+import (
+	"context"
+	"time"
+)
+
+type nilProcessor struct {
+	duration time.Duration
+}
+
+// Nil returns a Processor that waits duration and then closes errs without
+// sending anything. It's primarily useful for tests and benchmarks.
+func Nil(duration time.Duration) Processor {
+	return &nilProcessor{
+		duration: duration,
+	}
+}
+
+// Process waits for p.duration and then closes errs.
+func (p *nilProcessor) Process(ctx context.Context, items []interface{}, errs chan<- error) {
+	defer close(errs)
+
+	select {
+	case <-time.After(p.duration):
+	case <-ctx.Done():
+	}
+}
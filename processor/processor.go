@@ -0,0 +1,14 @@
+// Package processor provides Processor implementations that can be plugged
+// into a gobatch.Batch, as well as the Processor interface itself.
+package processor
+
+import "context"
+
+// Processor processes a batch of items read by a Source. Process may be
+// called from multiple goroutines at once, so implementations must be safe
+// for concurrent use.
+//
+// Process must close errs before returning.
+type Processor interface {
+	Process(ctx context.Context, items []interface{}, errs chan<- error)
+}
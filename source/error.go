@@ -1,25 +1,23 @@
 package source
 
-import (
-	"context"
-
-	"github.com/MasterOfBinary/gobatch/batch"
-)
+import "context"
 
 type errorSource struct {
 	err error
 }
 
-// Error returns a Source that returns an error and then closes immediately.
-// It can be used as a mock Source.
-func Error(err error) batch.Source {
+// Error returns a Source that sends err and then closes immediately. It can
+// be used as a mock Source.
+func Error(err error) Source {
 	return &errorSource{
 		err: err,
 	}
 }
 
-// Read returns an error and then closes.
-func (s *errorSource) Read(ctx context.Context, ps batch.PipelineStage) {
-	ps.Error() <- s.err
-	ps.Close()
+// Read sends err on errs and then closes both channels.
+func (s *errorSource) Read(ctx context.Context, items chan<- interface{}, errs chan<- error) {
+	defer close(items)
+	defer close(errs)
+
+	errs <- s.err
 }
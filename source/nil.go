@@ -0,0 +1,36 @@
+package source
+
+import (
+	"context"
+	"time"
+)
+
+type nilSource struct {
+	duration time.Duration
+}
+
+// Nil returns a Source that sends a single nil item after waiting duration,
+// and then closes. It's primarily useful for tests and benchmarks.
+func Nil(duration time.Duration) Source {
+	return &nilSource{
+		duration: duration,
+	}
+}
+
+// Read waits for s.duration and then sends a single nil item before closing
+// both channels.
+func (s *nilSource) Read(ctx context.Context, items chan<- interface{}, errs chan<- error) {
+	defer close(items)
+	defer close(errs)
+
+	select {
+	case <-time.After(s.duration):
+	case <-ctx.Done():
+		return
+	}
+
+	select {
+	case items <- nil:
+	case <-ctx.Done():
+	}
+}
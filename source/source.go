@@ -0,0 +1,15 @@
+// Package source provides Source implementations that can be plugged into a
+// gobatch.Batch, as well as the Source interface itself.
+package source
+
+import "context"
+
+// Source reads items to be batched. Read is called by a Batch, potentially
+// from multiple goroutines at once when BatchConfig.ReadConcurrency is
+// greater than one, so implementations must be safe for concurrent use.
+//
+// Read must close both items and errs before returning, even if it returns
+// early because ctx was canceled.
+type Source interface {
+	Read(ctx context.Context, items chan<- interface{}, errs chan<- error)
+}
@@ -0,0 +1,117 @@
+package gobatch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MasterOfBinary/gobatch/processor"
+)
+
+// gatedProcessor blocks every Process call on release until it's sent a
+// value, so tests can control exactly how many batches are in flight at
+// once, and records the highest number it ever saw concurrently.
+type gatedProcessor struct {
+	release <-chan struct{}
+
+	mu         sync.Mutex
+	concurrent int
+	maxSeen    int
+}
+
+func (p *gatedProcessor) Process(ctx context.Context, items []interface{}, errs chan<- error) {
+	defer close(errs)
+
+	p.mu.Lock()
+	p.concurrent++
+	if p.concurrent > p.maxSeen {
+		p.maxSeen = p.concurrent
+	}
+	p.mu.Unlock()
+
+	<-p.release
+
+	p.mu.Lock()
+	p.concurrent--
+	p.mu.Unlock()
+}
+
+func TestBatch_ProcessConcurrency(t *testing.T) {
+	t.Parallel()
+
+	const (
+		numBatches = 6
+		maxConc    = 2
+	)
+
+	release := make(chan struct{})
+	p := &gatedProcessor{release: release}
+
+	batch := Must(New(&BatchConfig{
+		MaxItems:           1,
+		ProcessConcurrency: maxConc,
+	}))
+
+	slice := make([]interface{}, numBatches)
+	for i := range slice {
+		slice[i] = i
+	}
+	s := &sourceFromSlice{slice: slice}
+
+	errs := batch.Go(context.Background(), s, p)
+	drainErrors(errs)
+
+	deadline := time.After(time.Second)
+	for {
+		stats := batch.Stats()
+		if stats.Running == maxConc && stats.Waiting == numBatches-maxConc {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("Running/Waiting never reached %d/%d, stuck at %+v", maxConc, numBatches-maxConc, stats)
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if stats := batch.Stats(); stats.Waiting != numBatches-maxConc {
+		t.Errorf("Waiting = %d, want %d", stats.Waiting, numBatches-maxConc)
+	}
+
+	for i := 0; i < numBatches; i++ {
+		release <- struct{}{}
+	}
+
+	<-batch.Done()
+
+	p.mu.Lock()
+	maxSeen := p.maxSeen
+	p.mu.Unlock()
+	if maxSeen > maxConc {
+		t.Errorf("Processor saw %d concurrent batches, want at most %d", maxSeen, maxConc)
+	}
+
+	if stats := batch.Stats(); stats.TotalProcessed != numBatches {
+		t.Errorf("TotalProcessed = %d, want %d", stats.TotalProcessed, numBatches)
+	}
+}
+
+func TestBatch_Stats_Unbounded(t *testing.T) {
+	t.Parallel()
+
+	batch := &Batch{}
+	s := &sourceFromSlice{slice: []interface{}{1, 2, 3}}
+	p := processor.Nil(0)
+
+	assertNoErrors(t, batch.Go(context.Background(), s, p))
+	<-batch.Done()
+
+	stats := batch.Stats()
+	if stats.Running != 0 || stats.Waiting != 0 {
+		t.Errorf("Stats = %+v after Done, want Running and Waiting both 0", stats)
+	}
+	if stats.TotalProcessed != 3 {
+		t.Errorf("TotalProcessed = %d, want 3", stats.TotalProcessed)
+	}
+}